@@ -0,0 +1,19 @@
+//go:build appengine
+// +build appengine
+
+package colly
+
+import (
+	"context"
+
+	"google.golang.org/appengine/urlfetch"
+)
+
+// Appengine configures the Collector to issue requests through Google App
+// Engine's urlfetch service instead of the default net/http transport.
+// Appengine is only compiled in under the appengine build tag, and must
+// be called with the request-scoped ctx handed to every App Engine
+// handler before the Collector makes any requests.
+func (c *Collector) Appengine(ctx context.Context) {
+	c.client.Transport = urlfetch.Client(ctx).Transport
+}
@@ -0,0 +1,96 @@
+package colly
+
+import (
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is the gob-encoded payload stored for a cached response
+type cacheEntry struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+}
+
+// isIdempotentMethod reports whether method's response can safely be
+// served from cache on a later identical request
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE", "TRACE":
+		return true
+	default:
+		return false
+	}
+}
+
+// cacheRequestHash computes the fnv64a cache key for a request: its
+// method, URL and body, plus the value of every header named in
+// cacheableHeaders that's present on hdr. Requests with the same method,
+// URL, body and cacheable header values always produce the same key; any
+// difference in the body,
+// including field order, is a different key.
+func cacheRequestHash(method, u string, body []byte, hdr http.Header, cacheableHeaders []string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(method))
+	h.Write([]byte(u))
+	h.Write(body)
+	for _, name := range cacheableHeaders {
+		if v := hdr.Get(name); v != "" {
+			h.Write([]byte(name))
+			h.Write([]byte(v))
+		}
+	}
+	return h.Sum64()
+}
+
+// cachePath shards key into dir/ab/cd/<hex> so a single directory never
+// accumulates more than a few hundred entries
+func cachePath(dir string, key uint64) string {
+	hex := fmt.Sprintf("%016x", key)
+	return filepath.Join(dir, hex[0:2], hex[2:4], hex)
+}
+
+// readCacheEntry loads the cache entry for key from dir. It returns an
+// error -- and the caller should treat that as a cache miss -- if no
+// entry exists, it can't be decoded, or expiration is non-zero and the
+// entry is older than expiration
+func readCacheEntry(dir string, key uint64, expiration time.Duration) (*cacheEntry, error) {
+	path := cachePath(dir, key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if expiration > 0 && time.Since(info.ModTime()) > expiration {
+		return nil, os.ErrNotExist
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entry := &cacheEntry{}
+	if err := gob.NewDecoder(f).Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// writeCacheEntry gob-encodes entry and stores it at dir's shard for key,
+// creating any missing shard directories
+func writeCacheEntry(dir string, key uint64, entry *cacheEntry) error {
+	path := cachePath(dir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gob.NewEncoder(f).Encode(entry)
+}
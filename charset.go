@@ -0,0 +1,47 @@
+package colly
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"mime"
+
+	"golang.org/x/net/html/charset"
+)
+
+// decompressBody wraps body in a gzip or deflate reader according to
+// contentEncoding, returning body unchanged for any other (or empty)
+// value. br (brotli) is not handled: the standard library has no brotli
+// reader and this repo doesn't vendor one, so a "Content-Encoding: br"
+// response is passed through undecoded rather than decompressed.
+func decompressBody(body io.Reader, contentEncoding string) (io.Reader, error) {
+	switch contentEncoding {
+	case "gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		return flate.NewReader(body), nil
+	default:
+		return body, nil
+	}
+}
+
+// decodeCharset transcodes body to UTF-8. It determines the source
+// encoding from, in order, the charset in contentType, a byte-order mark,
+// and a <meta charset> sniff of the first 1024 bytes, via
+// golang.org/x/net/html/charset. If none of these names an encoding, a
+// body that is valid UTF-8 (or plain ASCII) comes back unchanged and any
+// other body is decoded as windows-1252.
+func decodeCharset(body []byte, contentType string) ([]byte, error) {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if e, _ := charset.Lookup(params["charset"]); e != nil {
+			return e.NewDecoder().Bytes(body)
+		}
+	}
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return body, err
+	}
+	return ioutil.ReadAll(r)
+}
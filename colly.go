@@ -3,16 +3,24 @@ package colly
 
 import (
 	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
-	"golang.org/x/net/html"
+	"github.com/temoto/robotstxt"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/aleksey0909/collygolang/storage"
 )
 
 // Collector provides the scraper instance for a scraping job
@@ -21,14 +29,67 @@ type Collector struct {
 	UserAgent string
 	// MaxDepth limits the recursion depth of visited URLs.
 	// Set it to 0 for infinite recursion (default).
-	MaxDepth          int
-	visitedURLs       []string
-	htmlCallbacks     map[string]HTMLCallback
-	requestCallbacks  []RequestCallback
-	responseCallbacks []ResponseCallback
-	client            *http.Client
-	wg                *sync.WaitGroup
-	lock              *sync.Mutex
+	MaxDepth int
+	// Async turns on asynchronous network communication. Use Collector.Wait
+	// to block until all requests are finished.
+	Async bool
+	// ParseHTTPErrorResponse allows parsing responses with HTTP errors
+	// (status codes outside the 2xx range). By default these responses
+	// are dropped after firing OnError.
+	ParseHTTPErrorResponse bool
+	// AllowedDomains is a whitelist of domains allowed to be visited. An
+	// empty slice means all domains are allowed.
+	AllowedDomains []string
+	// DisallowedDomains is a blacklist of domains not allowed to be
+	// visited, checked before AllowedDomains.
+	DisallowedDomains []string
+	// URLFilters is a whitelist of regular expressions a URL must match
+	// at least one of. An empty slice means all URLs are allowed.
+	URLFilters []*regexp.Regexp
+	// DisallowedURLFilters is a blacklist of regular expressions, checked
+	// before URLFilters.
+	DisallowedURLFilters []*regexp.Regexp
+	// AllowURLRevisit allows multiple downloads of the same URL
+	AllowURLRevisit bool
+	// IgnoreRobotsTxt disables robots.txt checks for every request
+	IgnoreRobotsTxt bool
+	// MaxBodySize is the limit in bytes of the retrieved response body.
+	// 0 means unlimited.
+	MaxBodySize int
+	// DetectCharset enables automatic charset detection and transcoding
+	// of the response body to UTF-8 before it's handed to OnHTML/OnXML.
+	// Defaults to true.
+	DetectCharset bool
+	// CacheDir specifies a directory to cache responses in, keyed by
+	// request hash. An empty string (the default) disables caching.
+	CacheDir string
+	// CacheExpiration is how long a cached response in CacheDir stays
+	// valid. Zero means cached responses never expire.
+	CacheExpiration time.Duration
+	// CacheableHeaders lists request header names whose values are
+	// folded into the cache key, for endpoints whose response depends on
+	// a header such as Accept-Language. Headers not listed here don't
+	// affect which cache entry a request hits.
+	CacheableHeaders []string
+	// Debug turns on logging of every request, and of every request whose
+	// HTTP call fails, to stderr
+	Debug                    bool
+	disableCookies           bool
+	storage                  storage.Storage
+	robotsMap                map[string]*robotstxt.RobotsData
+	htmlCallbacks            map[string]HTMLCallback
+	xmlCallbacks             map[string]XMLCallback
+	requestCallbacks         []RequestCallback
+	responseCallbacks        []ResponseCallback
+	responseHeadersCallbacks []ResponseHeadersCallback
+	errorCallbacks           []ErrorCallback
+	scrapedCallbacks         []ScrapedCallback
+	client                   *http.Client
+	wg                       *sync.WaitGroup
+	lock                     *sync.Mutex
+	limitRules               []*LimitRule
+	limiters                 map[string]*domainLimiter
+	queue                    *Queue
 }
 
 // Request is the representation of a HTTP request made by a Collector
@@ -42,36 +103,54 @@ type Request struct {
 	// Ctx is a context between a Request and a Response
 	Ctx *Context
 	// Depth is the number of the parents of this request
-	Depth     int
+	Depth int
+	// Method is the HTTP method of the request, e.g. "GET" or "POST"
+	Method string
+	// Body is the request body
+	Body []byte
+	// ProxyURL is the proxy address used for this request, if any
+	ProxyURL string
+
+	aborted   bool
 	collector *Collector
 }
 
+// serializableRequest is the JSON-friendly projection of a Request used by
+// Request.Marshal/Unmarshal. CookieJar and the owning Collector can't
+// survive a round-trip through Storage or a message queue, so they're
+// left out; Ctx carries only the string values set through Context.Put.
+type serializableRequest struct {
+	URL      string
+	Method   string
+	Depth    int
+	Body     []byte
+	ProxyURL string
+	Ctx      map[string]string
+}
+
 // Response is the representation of a HTTP response made by a Collector
 type Response struct {
 	// StatusCode is the status code of the Response
 	StatusCode int
-	// Body is the content of the Response
+	// Body is the content of the Response, decompressed and, if
+	// Collector.DetectCharset is enabled, transcoded to UTF-8
 	Body []byte
+	// RawBody is Body before charset detection/transcoding, for callers
+	// that need the original bytes
+	RawBody []byte
+	// Headers contains the Response's HTTP headers. It is populated as
+	// soon as the headers arrive, before Body is read.
+	Headers *http.Header
 	// Ctx is a context between a Request and a Response
 	Ctx *Context
 	// Request is the Request object of the response
 	Request *Request
 }
 
-// HTMLElement is the representation of a HTML tag.
-type HTMLElement struct {
-	// Name is the name of the tag
-	Name       string
-	attributes []html.Attribute
-	// Request is the request object of the element's HTML document
-	Request *Request
-	// Response is the Response object of the element's HTML document
-	Response *Response
-}
-
 // Context provides a tiny layer for passing data between different methods
 type Context struct {
 	contextMap map[string]string
+	anyMap     map[string]interface{}
 	lock       *sync.Mutex
 }
 
@@ -81,6 +160,16 @@ type RequestCallback func(*Request)
 // ResponseCallback is a type alias for OnResponse callback functions
 type ResponseCallback func(*Response)
 
+// ResponseHeadersCallback is a type alias for OnResponseHeaders callback
+// functions
+type ResponseHeadersCallback func(*Response)
+
+// ErrorCallback is a type alias for OnError callback functions
+type ErrorCallback func(*Response, error)
+
+// ScrapedCallback is a type alias for OnScraped callback functions
+type ScrapedCallback func(*Response)
+
 // HTMLCallback is a type alias for OnHTML callback functions
 type HTMLCallback func(*HTMLElement)
 
@@ -95,6 +184,7 @@ func NewCollector() *Collector {
 func NewContext() *Context {
 	return &Context{
 		contextMap: make(map[string]string),
+		anyMap:     make(map[string]interface{}),
 		lock:       &sync.Mutex{},
 	}
 }
@@ -104,16 +194,23 @@ func NewContext() *Context {
 func (c *Collector) Init() {
 	c.UserAgent = "colly - https://github.com/asciimoo/colly"
 	c.MaxDepth = 0
-	c.visitedURLs = make([]string, 0, 8)
+	c.DetectCharset = true
+	c.storage = &storage.InMemoryStorage{}
+	c.storage.Init()
+	c.robotsMap = make(map[string]*robotstxt.RobotsData)
 	c.htmlCallbacks = make(map[string]HTMLCallback, 0)
+	c.xmlCallbacks = make(map[string]XMLCallback, 0)
 	c.requestCallbacks = make([]RequestCallback, 0, 8)
 	c.responseCallbacks = make([]ResponseCallback, 0, 8)
-	jar, _ := cookiejar.New(nil)
-	c.client = &http.Client{
-		Jar: jar,
-	}
+	c.responseHeadersCallbacks = make([]ResponseHeadersCallback, 0, 4)
+	c.errorCallbacks = make([]ErrorCallback, 0, 4)
+	c.scrapedCallbacks = make([]ScrapedCallback, 0, 4)
+	c.client = &http.Client{}
 	c.wg = &sync.WaitGroup{}
 	c.lock = &sync.Mutex{}
+	c.limitRules = make([]*LimitRule, 0, 4)
+	c.limiters = make(map[string]*domainLimiter)
+	c.queue = newQueue(c, defaultQueueWorkers)
 }
 
 // Visit starts Collector's collecting job by creating a
@@ -121,77 +218,288 @@ func (c *Collector) Init() {
 // Visit also calls the previously provided OnRequest,
 // OnResponse, OnHTML callbacks
 func (c *Collector) Visit(u string) error {
-	return c.scrape(u, 1)
+	return c.scrape(u, "GET", 1, nil, nil, nil)
 }
 
-func (c *Collector) scrape(u string, depth int) error {
-	c.wg.Add(1)
-	defer c.wg.Done()
+// Request starts a collector job by creating a custom HTTP request. Unlike
+// Visit, Request lets the caller choose the HTTP method, send a body and
+// seed the Request's Context and headers before it is dispatched. Request
+// also calls the previously provided OnRequest, OnResponse, OnHTML
+// callbacks.
+func (c *Collector) Request(method, URL string, body io.Reader, ctx *Context, hdr http.Header) error {
+	return c.scrape(URL, method, 1, body, ctx, hdr)
+}
+
+// PostRaw starts a collector job by creating a POST request with
+// requestData sent verbatim as the request body, without any form
+// encoding. It's suited to JSON/XML APIs and other
+// non-form POST endpoints.
+func (c *Collector) PostRaw(URL string, requestData []byte) error {
+	return c.scrape(URL, "POST", 1, bytes.NewReader(requestData), nil, nil)
+}
+
+// PostMultipart starts a collector job by creating a multipart/form-data
+// POST request out of requestData, one part per map entry keyed by field
+// name. It's suited to file uploads and other requests plain form
+// encoding can't express.
+func (c *Collector) PostMultipart(URL string, requestData map[string][]byte) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, content := range requestData {
+		part, err := w.CreateFormField(name)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	hdr := http.Header{}
+	hdr.Set("Content-Type", w.FormDataContentType())
+	return c.scrape(URL, "POST", 1, &buf, nil, hdr)
+}
+
+// scrape validates u against MaxDepth, the configured filters, revisit
+// policy and robots.txt, then either dispatches the request to the async
+// queue or runs it synchronously
+func (c *Collector) scrape(u, method string, depth int, requestData io.Reader, ctx *Context, hdr http.Header) error {
+	return c.doScrape(u, method, depth, requestData, ctx, hdr, false)
+}
+
+// doScrape is scrape with the revisit check made optional, so
+// Request.Retry can resend a request scrape already marked visited on
+// its first, failed attempt
+func (c *Collector) doScrape(u, method string, depth int, requestData io.Reader, ctx *Context, hdr http.Header, skipVisitedCheck bool) error {
 	if u == "" {
 		return nil
 	}
 	if c.MaxDepth > 0 && c.MaxDepth < depth {
 		return nil
 	}
-	visited := false
-	for _, u2 := range c.visitedURLs {
-		if u2 == u {
-			visited = true
-			break
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if err := c.checkFilters(u, parsedURL.Hostname()); err != nil {
+		return err
+	}
+	if !c.IgnoreRobotsTxt {
+		if err := c.checkRobots(parsedURL); err != nil {
+			return err
+		}
+	}
+	if !c.AllowURLRevisit && !skipVisitedCheck {
+		hash := requestHash(u)
+		visited, err := c.storage.IsVisited(hash)
+		if err != nil {
+			return err
+		}
+		if visited {
+			return ErrAlreadyVisited
+		}
+		if err := c.storage.Visited(hash); err != nil {
+			return err
+		}
+	}
+
+	var body []byte
+	if requestData != nil {
+		body, err = ioutil.ReadAll(requestData)
+		if err != nil {
+			return err
 		}
 	}
-	if visited {
+
+	c.wg.Add(1)
+	if c.Async {
+		if err := c.queue.push(u, method, depth, body, ctx, hdr); err != nil {
+			c.wg.Done()
+			return err
+		}
 		return nil
 	}
-	c.lock.Lock()
-	c.visitedURLs = append(c.visitedURLs, u)
-	c.lock.Unlock()
+	return c.fetch(u, method, depth, body, ctx, hdr)
+}
+
+// fetch performs the actual HTTP request for u and runs the registered
+// callbacks against the response. It is called directly for synchronous
+// collectors and from Queue workers for asynchronous ones. Callbacks fire
+// in the order OnRequest, OnError, OnResponseHeaders, OnResponse, OnHTML,
+// OnXML, OnScraped; OnError can additionally fire after OnResponseHeaders
+// if the body can't be read or the status code is outside the 2xx range.
+func (c *Collector) fetch(u, method string, depth int, requestData []byte, ctx *Context, hdr http.Header) error {
+	defer c.wg.Done()
 	parsedURL, err := url.Parse(u)
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("GET", u, nil)
+	limiter := c.limiterFor(parsedURL.Hostname())
+	limiter.wait()
+	defer limiter.done()
+
+	if method == "" {
+		method = "GET"
+	}
+	var bodyReader io.Reader
+	if requestData != nil {
+		bodyReader = bytes.NewReader(requestData)
+	}
+	req, err := http.NewRequest(method, u, bodyReader)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("User-Agent", c.UserAgent)
-	ctx := NewContext()
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	if !c.disableCookies {
+		if cookies := c.storage.Cookies(parsedURL); cookies != "" {
+			req.Header.Set("Cookie", cookies)
+		}
+	}
+	if ctx == nil {
+		ctx = NewContext()
+	}
 	request := &Request{
 		URL:       parsedURL,
 		Headers:   &req.Header,
 		Ctx:       ctx,
 		Depth:     depth,
+		Method:    method,
+		Body:      requestData,
 		collector: c,
 	}
 	if len(c.requestCallbacks) > 0 {
 		c.handleOnRequest(request)
 	}
-	res, err := c.client.Do(req)
-	if err != nil {
-		return err
+	if request.aborted {
+		return ErrAbortedByRequest
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return err
+	client := c.client
+	if request.ProxyURL != "" {
+		proxyURL, err := url.Parse(request.ProxyURL)
+		if err != nil {
+			return err
+		}
+		client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
 	}
-	res.Body.Close()
-	response := &Response{
-		StatusCode: res.StatusCode,
-		Body:       body,
-		Ctx:        ctx,
+	if c.Debug {
+		log.Printf("%s %s (depth: %d)", method, u, depth)
 	}
-	if strings.Index(strings.ToLower(res.Header.Get("Content-Type")), "html") > -1 {
-		c.handleOnHTML(body, request, response)
+
+	cacheable := c.CacheDir != "" && isIdempotentMethod(method)
+	var cacheKey uint64
+	if cacheable {
+		cacheKey = cacheRequestHash(method, u, requestData, req.Header, c.CacheableHeaders)
+	}
+
+	var statusCode int
+	var resHeader http.Header
+	var rawBody, body []byte
+	fromCache := false
+	if cacheable {
+		if entry, cerr := readCacheEntry(c.CacheDir, cacheKey, c.CacheExpiration); cerr == nil {
+			statusCode, resHeader, rawBody = entry.StatusCode, entry.Headers, entry.Body
+			body = rawBody
+			if c.DetectCharset {
+				if decoded, decErr := decodeCharset(rawBody, resHeader.Get("Content-Type")); decErr == nil {
+					body = decoded
+				}
+			}
+			fromCache = true
+		}
+	}
+
+	var response *Response
+	if fromCache {
+		response = &Response{StatusCode: statusCode, Headers: &resHeader, Ctx: ctx, Request: request, RawBody: rawBody, Body: body}
+		if len(c.responseHeadersCallbacks) > 0 {
+			c.handleOnResponseHeaders(response)
+		}
+	} else {
+		res, err := client.Do(req)
+		if err != nil {
+			if c.Debug {
+				log.Printf("%s %s failed: %s", method, u, err)
+			}
+			if len(c.errorCallbacks) > 0 {
+				c.handleOnError(&Response{Ctx: ctx, Request: request}, err)
+			}
+			return err
+		}
+		if !c.disableCookies {
+			if setCookie := res.Header["Set-Cookie"]; len(setCookie) > 0 {
+				c.storage.SetCookies(parsedURL, strings.Join(setCookie, "; "))
+			}
+		}
+		statusCode, resHeader = res.StatusCode, res.Header
+		response = &Response{StatusCode: statusCode, Headers: &resHeader, Ctx: ctx, Request: request}
+		if len(c.responseHeadersCallbacks) > 0 {
+			c.handleOnResponseHeaders(response)
+		}
+		respBodyReader, err := decompressBody(res.Body, strings.ToLower(res.Header.Get("Content-Encoding")))
+		if err != nil {
+			res.Body.Close()
+			if len(c.errorCallbacks) > 0 {
+				c.handleOnError(response, err)
+			}
+			return err
+		}
+		if c.MaxBodySize > 0 {
+			respBodyReader = io.LimitReader(respBodyReader, int64(c.MaxBodySize))
+		}
+		rawBody, err = ioutil.ReadAll(respBodyReader)
+		res.Body.Close()
+		if err != nil {
+			if len(c.errorCallbacks) > 0 {
+				c.handleOnError(response, err)
+			}
+			return err
+		}
+		body = rawBody
+		if c.DetectCharset {
+			if decoded, decErr := decodeCharset(rawBody, resHeader.Get("Content-Type")); decErr == nil {
+				body = decoded
+			}
+		}
+		response.RawBody = rawBody
+		response.Body = body
+		if cacheable && statusCode >= 200 && statusCode < 300 {
+			writeCacheEntry(c.CacheDir, cacheKey, &cacheEntry{StatusCode: statusCode, Headers: resHeader, Body: rawBody})
+		}
+	}
+	var httpErr error
+	if statusCode < 200 || statusCode >= 300 {
+		httpErr = fmt.Errorf("Status code: %d", statusCode)
+		if len(c.errorCallbacks) > 0 {
+			c.handleOnError(response, httpErr)
+		}
+		if !c.ParseHTTPErrorResponse {
+			return httpErr
+		}
 	}
 	if len(c.responseCallbacks) > 0 {
 		c.handleOnResponse(response)
 	}
-	return nil
+	if strings.Index(strings.ToLower(resHeader.Get("Content-Type")), "html") > -1 {
+		c.handleOnHTML(body, request, response)
+	}
+	if len(c.xmlCallbacks) > 0 {
+		c.handleOnXML(body, request, response)
+	}
+	if len(c.scrapedCallbacks) > 0 {
+		c.handleOnScraped(response)
+	}
+	return httpErr
 }
 
-// Wait returns when the collector jobs are finished
+// Wait blocks until all pending requests -- including queued ones started
+// by an Async Collector -- are finished
 func (c *Collector) Wait() {
-	c.wg.Done()
+	c.wg.Wait()
 }
 
 // OnRequest registers a function. Function will be executed on every
@@ -217,9 +525,58 @@ func (c *Collector) OnHTML(goquerySelector string, f HTMLCallback) {
 	c.lock.Unlock()
 }
 
+// OnXML registers a function. Function will be executed on every XML
+// element matched by the xpath query parameter. Matching is performed
+// against the HTML tree for HTML responses and the XML tree otherwise,
+// based on the response's Content-Type.
+func (c *Collector) OnXML(xpathQuery string, f XMLCallback) {
+	c.lock.Lock()
+	c.xmlCallbacks[xpathQuery] = f
+	c.lock.Unlock()
+}
+
+// OnResponseHeaders registers a function. Function will be executed after
+// a response's headers were received, before the body is downloaded.
+func (c *Collector) OnResponseHeaders(f ResponseHeadersCallback) {
+	c.lock.Lock()
+	c.responseHeadersCallbacks = append(c.responseHeadersCallbacks, f)
+	c.lock.Unlock()
+}
+
+// OnError registers a function. Function will be executed if an error
+// occurs during the HTTP request, the body could not be read, or the
+// response's status code is outside the 2xx range.
+func (c *Collector) OnError(f ErrorCallback) {
+	c.lock.Lock()
+	c.errorCallbacks = append(c.errorCallbacks, f)
+	c.lock.Unlock()
+}
+
+// OnScraped registers a function. Function will be executed after
+// OnHTML, OnXML and all other callbacks were already called.
+func (c *Collector) OnScraped(f ScrapedCallback) {
+	c.lock.Lock()
+	c.scrapedCallbacks = append(c.scrapedCallbacks, f)
+	c.lock.Unlock()
+}
+
 // DisableCookies turns off cookie handling for this collector
 func (c *Collector) DisableCookies() {
-	c.client.Jar = nil
+	c.disableCookies = true
+}
+
+// SetStorage overrides the Collector's default in-memory Storage with s.
+// s.Init is called before it takes over, so a failing backend (e.g. an
+// unreachable Redis server) is reported immediately instead of on first
+// use.
+func (c *Collector) SetStorage(s storage.Storage) error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.storage = s
+	c.lock.Unlock()
+	return nil
 }
 
 func (c *Collector) handleOnRequest(r *Request) {
@@ -234,34 +591,22 @@ func (c *Collector) handleOnResponse(r *Response) {
 	}
 }
 
-func (c *Collector) handleOnHTML(body []byte, req *Request, resp *Response) {
-	doc, err := goquery.NewDocumentFromReader(bytes.NewBuffer(body))
-	if err != nil {
-		return
-	}
-	for expr, f := range c.htmlCallbacks {
-		doc.Find(expr).Each(func(i int, s *goquery.Selection) {
-			for _, n := range s.Nodes {
-				f(&HTMLElement{
-					Name:       n.Data,
-					Request:    req,
-					Response:   resp,
-					attributes: n.Attr,
-				})
-			}
-		})
+func (c *Collector) handleOnResponseHeaders(r *Response) {
+	for _, f := range c.responseHeadersCallbacks {
+		f(r)
 	}
 }
 
-// Attr returns the selected attribute of a HTMLElement or empty string
-// if no attribute found
-func (h *HTMLElement) Attr(k string) string {
-	for _, a := range h.attributes {
-		if a.Key == k {
-			return a.Val
-		}
+func (c *Collector) handleOnError(r *Response, err error) {
+	for _, f := range c.errorCallbacks {
+		f(r, err)
+	}
+}
+
+func (c *Collector) handleOnScraped(r *Response) {
+	for _, f := range c.scrapedCallbacks {
+		f(r)
 	}
-	return ""
 }
 
 // AbsoluteURL returns with the resolved absolute URL of an URL chunk.
@@ -287,7 +632,81 @@ func (r *Request) AbsoluteURL(u string) string {
 // Visit also calls the previously provided OnRequest,
 // OnResponse, OnHTML callbacks
 func (r *Request) Visit(u string) error {
-	return r.collector.scrape(r.AbsoluteURL(u), r.Depth+1)
+	return r.collector.scrape(r.AbsoluteURL(u), "GET", r.Depth+1, nil, nil, nil)
+}
+
+// Retry re-sends the Request using the same method, URL, body, headers and
+// Context. ProxyURL is not carried over: the re-sent request starts with
+// an empty ProxyURL, as any new request does, and an OnRequest callback
+// can set it again. Retry is meant to be called from an OnError callback
+// to retry a failed request, possibly after mutating Ctx first. Unlike
+// a fresh Visit, Retry bypasses the visited-URL check -- scrape marks a
+// URL visited before it's ever fetched, so without this a Retry of a
+// request that failed on its first attempt would always come back
+// ErrAlreadyVisited instead of actually resending it.
+func (r *Request) Retry() error {
+	var body io.Reader
+	if r.Body != nil {
+		body = bytes.NewReader(r.Body)
+	}
+	return r.collector.doScrape(r.URL.String(), r.Method, r.Depth, body, r.Ctx, *r.Headers, true)
+}
+
+// Abort cancels the Request. Abort only has an effect when called from an
+// OnRequest callback; the HTTP request is never sent and
+// ErrAbortedByRequest is returned from the call that triggered the
+// callback (Visit, Request, Collector.PostRaw, ...). On an Async Collector
+// that call has already returned nil once the request was queued, so the
+// error is not reported to the caller.
+func (r *Request) Abort() {
+	r.aborted = true
+}
+
+// Marshal serializes the Request into JSON so it can be handed off to
+// Storage or a distributed worker and reconstructed elsewhere with
+// Unmarshal. CookieJar, Headers and any values stored in Ctx with PutAny
+// are not preserved; use Context.Marshal separately for the PutAny values.
+func (r *Request) Marshal() ([]byte, error) {
+	ctxValues := map[string]string{}
+	if r.Ctx != nil {
+		r.Ctx.lock.Lock()
+		for k, v := range r.Ctx.contextMap {
+			ctxValues[k] = v
+		}
+		r.Ctx.lock.Unlock()
+	}
+	return json.Marshal(serializableRequest{
+		URL:      r.URL.String(),
+		Method:   r.Method,
+		Depth:    r.Depth,
+		Body:     r.Body,
+		ProxyURL: r.ProxyURL,
+		Ctx:      ctxValues,
+	})
+}
+
+// Unmarshal decodes a payload produced by Marshal into r, replacing its
+// URL, Method, Depth, Body, ProxyURL and Ctx
+func (r *Request) Unmarshal(data []byte) error {
+	sr := serializableRequest{}
+	if err := json.Unmarshal(data, &sr); err != nil {
+		return err
+	}
+	parsedURL, err := url.Parse(sr.URL)
+	if err != nil {
+		return err
+	}
+	ctx := NewContext()
+	for k, v := range sr.Ctx {
+		ctx.Put(k, v)
+	}
+	r.URL = parsedURL
+	r.Method = sr.Method
+	r.Depth = sr.Depth
+	r.Body = sr.Body
+	r.ProxyURL = sr.ProxyURL
+	r.Ctx = ctx
+	return nil
 }
 
 // Put stores a value in Context
@@ -305,3 +724,41 @@ func (c *Context) Get(k string) string {
 	}
 	return ""
 }
+
+// PutAny stores an arbitrary value in Context, unlike Put which is
+// restricted to strings
+func (c *Context) PutAny(k string, v interface{}) {
+	c.lock.Lock()
+	c.anyMap[k] = v
+	c.lock.Unlock()
+}
+
+// GetAny retrieves a value stored with PutAny. GetAny returns nil if no
+// value was found for `k`
+func (c *Context) GetAny(k string) interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.anyMap[k]
+}
+
+// Marshal encodes the values stored with PutAny using encoding/gob so
+// Context can be sent to another process (e.g. alongside a Request.Marshal
+// payload on a distributed queue). Types stored with PutAny must be
+// registered with gob.Register beforehand.
+func (c *Context) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	c.lock.Lock()
+	err := gob.NewEncoder(&buf).Encode(c.anyMap)
+	c.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a payload produced by Marshal back into Context
+func (c *Context) Unmarshal(data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(&c.anyMap)
+}
@@ -0,0 +1,66 @@
+package colly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestCallbackOrder verifies that a successful request fires callbacks in
+// the documented order: OnRequest, OnResponseHeaders, OnResponse, OnHTML,
+// OnXML, OnScraped -- with no OnError call.
+func TestCallbackOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>hi</p></body></html>"))
+	}))
+	defer ts.Close()
+
+	var order []string
+	c := NewCollector()
+	c.OnRequest(func(r *Request) { order = append(order, "OnRequest") })
+	c.OnError(func(r *Response, err error) { order = append(order, "OnError") })
+	c.OnResponseHeaders(func(r *Response) { order = append(order, "OnResponseHeaders") })
+	c.OnResponse(func(r *Response) { order = append(order, "OnResponse") })
+	c.OnHTML("p", func(e *HTMLElement) { order = append(order, "OnHTML") })
+	c.OnXML("//p", func(e *XMLElement) { order = append(order, "OnXML") })
+	c.OnScraped(func(r *Response) { order = append(order, "OnScraped") })
+
+	if err := c.Visit(ts.URL); err != nil {
+		t.Fatalf("Visit returned error: %v", err)
+	}
+
+	want := []string{"OnRequest", "OnResponseHeaders", "OnResponse", "OnHTML", "OnXML", "OnScraped"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("callback order = %v, want %v", order, want)
+	}
+}
+
+// TestCallbackOrderError verifies that a non-2xx response additionally
+// fires OnError, between OnResponseHeaders and OnResponse, and that
+// ParseHTTPErrorResponse lets the remaining callbacks still run.
+func TestCallbackOrderError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	var order []string
+	c := NewCollector()
+	c.ParseHTTPErrorResponse = true
+	c.OnRequest(func(r *Request) { order = append(order, "OnRequest") })
+	c.OnResponseHeaders(func(r *Response) { order = append(order, "OnResponseHeaders") })
+	c.OnError(func(r *Response, err error) { order = append(order, "OnError") })
+	c.OnResponse(func(r *Response) { order = append(order, "OnResponse") })
+	c.OnScraped(func(r *Response) { order = append(order, "OnScraped") })
+
+	if err := c.Visit(ts.URL); err == nil {
+		t.Fatal("Visit returned nil error for a 500 response")
+	}
+
+	want := []string{"OnRequest", "OnResponseHeaders", "OnError", "OnResponse", "OnScraped"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("callback order = %v, want %v", order, want)
+	}
+}
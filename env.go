@@ -0,0 +1,79 @@
+package colly
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NewCollectorFromEnv creates a new Collector with the default
+// configuration and then applies any COLLY_* environment variables set
+// in the process, via ParseEnvVariables. It lets a deployment reconfigure
+// a Collector without touching code.
+func NewCollectorFromEnv() *Collector {
+	c := NewCollector()
+	c.ParseEnvVariables()
+	return c
+}
+
+// ParseEnvVariables overrides the Collector's configuration with any of
+// the following environment variables that are set:
+//
+//	COLLY_USER_AGENT
+//	COLLY_MAX_DEPTH
+//	COLLY_ALLOWED_DOMAINS
+//	COLLY_DISALLOWED_DOMAINS
+//	COLLY_IGNORE_ROBOTSTXT
+//	COLLY_MAX_BODY_SIZE
+//	COLLY_CACHE_DIR
+//	COLLY_DETECT_CHARSET
+//	COLLY_PARSE_HTTP_ERROR_RESPONSE
+//	COLLY_DEBUG
+//
+// Domain lists are comma-separated; booleans accept anything
+// strconv.ParseBool understands. A variable that is unset or fails to
+// parse leaves the corresponding field untouched.
+func (c *Collector) ParseEnvVariables() {
+	if v := os.Getenv("COLLY_USER_AGENT"); v != "" {
+		c.UserAgent = v
+	}
+	if v := os.Getenv("COLLY_MAX_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.MaxDepth = n
+		}
+	}
+	if v := os.Getenv("COLLY_ALLOWED_DOMAINS"); v != "" {
+		c.AllowedDomains = strings.Split(v, ",")
+	}
+	if v := os.Getenv("COLLY_DISALLOWED_DOMAINS"); v != "" {
+		c.DisallowedDomains = strings.Split(v, ",")
+	}
+	if v := os.Getenv("COLLY_IGNORE_ROBOTSTXT"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.IgnoreRobotsTxt = b
+		}
+	}
+	if v := os.Getenv("COLLY_MAX_BODY_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.MaxBodySize = n
+		}
+	}
+	if v := os.Getenv("COLLY_CACHE_DIR"); v != "" {
+		c.CacheDir = v
+	}
+	if v := os.Getenv("COLLY_DETECT_CHARSET"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.DetectCharset = b
+		}
+	}
+	if v := os.Getenv("COLLY_PARSE_HTTP_ERROR_RESPONSE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.ParseHTTPErrorResponse = b
+		}
+	}
+	if v := os.Getenv("COLLY_DEBUG"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.Debug = b
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package colly
+
+import "errors"
+
+// Sentinel errors returned by Collector.Visit / Collector.scrape before a
+// request is ever sent.
+var (
+	// ErrForbiddenDomain is returned when the domain of a URL does not
+	// satisfy AllowedDomains/DisallowedDomains
+	ErrForbiddenDomain = errors.New("Forbidden domain")
+	// ErrForbiddenURL is returned when a URL matches DisallowedURLFilters
+	ErrForbiddenURL = errors.New("ForbiddenURL")
+	// ErrNoURLFiltersMatch is returned when URLFilters is set and none of
+	// its patterns match the URL
+	ErrNoURLFiltersMatch = errors.New("No URLFilters match")
+	// ErrAlreadyVisited is returned when a URL was already visited and
+	// AllowURLRevisit is false
+	ErrAlreadyVisited = errors.New("URL already visited")
+	// ErrRobotsTxtBlocked is returned when a URL is disallowed by the
+	// target host's robots.txt
+	ErrRobotsTxtBlocked = errors.New("URL blocked by robots.txt")
+	// ErrAbortedByRequest is returned when Request.Abort was called from
+	// an OnRequest callback
+	ErrAbortedByRequest = errors.New("Request aborted")
+)
@@ -0,0 +1,68 @@
+// Package extensions provides optional, self-contained behaviors that can
+// be attached to a Collector. Each extension takes the Collector as its
+// first argument -- RandomUserAgent and Referer have the plug-in shape
+// func(*colly.Collector), while URLLengthFilter also takes the length
+// limit -- so it's applied by calling it directly:
+//
+//	c := colly.NewCollector()
+//	extensions.RandomUserAgent(c)
+//	extensions.Referer(c)
+package extensions
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/aleksey0909/collygolang"
+)
+
+// userAgents is a small pool of realistic desktop browser User-Agent
+// strings RandomUserAgent picks from
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:115.0) Gecko/20100101 Firefox/115.0",
+}
+
+// RandomUserAgent makes c send a random entry from a small pool of common
+// browser User-Agent strings with every request, picked anew each time
+func RandomUserAgent(c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		r.Headers.Set("User-Agent", userAgents[rand.Intn(len(userAgents))])
+	})
+}
+
+// Referer makes c set the Referer header of every outgoing Request to the
+// URL most recently visited, mimicking a browser following links from
+// page to page. lastURL is guarded by a mutex because OnRequest and
+// OnResponse can run concurrently across an Async Collector's workers.
+func Referer(c *colly.Collector) {
+	var lock sync.Mutex
+	var lastURL string
+	c.OnResponse(func(r *colly.Response) {
+		lock.Lock()
+		lastURL = r.Request.URL.String()
+		lock.Unlock()
+	})
+	c.OnRequest(func(r *colly.Request) {
+		lock.Lock()
+		referer := lastURL
+		lock.Unlock()
+		if referer != "" {
+			r.Headers.Set("Referer", referer)
+		}
+	})
+}
+
+// URLLengthFilter makes c abort any Request whose URL is longer than
+// maxLength characters, guarding against runaway link chains such as
+// calendar or search pages that keep appending query parameters to
+// themselves
+func URLLengthFilter(c *colly.Collector, maxLength int) {
+	c.OnRequest(func(r *colly.Request) {
+		if len(r.URL.String()) > maxLength {
+			r.Abort()
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package colly
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// checkFilters enforces DisallowedURLFilters, URLFilters,
+// DisallowedDomains and AllowedDomains against a candidate URL, in that
+// order, before any request is built.
+func (c *Collector) checkFilters(u, domain string) error {
+	for _, r := range c.DisallowedURLFilters {
+		if r.MatchString(u) {
+			return ErrForbiddenURL
+		}
+	}
+	if len(c.URLFilters) > 0 {
+		matched := false
+		for _, r := range c.URLFilters {
+			if r.MatchString(u) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return ErrNoURLFiltersMatch
+		}
+	}
+	if len(c.DisallowedDomains) > 0 && isMatchingDomain(domain, c.DisallowedDomains) {
+		return ErrForbiddenDomain
+	}
+	if len(c.AllowedDomains) > 0 && !isMatchingDomain(domain, c.AllowedDomains) {
+		return ErrForbiddenDomain
+	}
+	return nil
+}
+
+// isMatchingDomain reports whether domain equals or is a subdomain of one
+// of the given domains
+func isMatchingDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestHash returns a fnv-64a hash of u, used as the key for the
+// O(1) visited-URL lookup
+func requestHash(u string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(u))
+	return h.Sum64()
+}
@@ -0,0 +1,129 @@
+package colly
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// HTMLElement is the representation of a HTML tag matched by a goquery
+// selector passed to OnHTML
+type HTMLElement struct {
+	// Name is the name of the tag
+	Name string
+	// Text is the concatenated, whitespace-trimmed text content of the
+	// element and its children
+	Text string
+	// Index is the position of this element within the set matched by
+	// the OnHTML selector
+	Index int
+	// DOM is the goquery.Selection this element was built from, for
+	// callers that need goquery directly
+	DOM *goquery.Selection
+	// Request is the request object of the element's HTML document
+	Request *Request
+	// Response is the Response object of the element's HTML document
+	Response   *Response
+	attributes []html.Attribute
+}
+
+// newHTMLElement builds a HTMLElement from the i-th match of a goquery
+// selection
+func newHTMLElement(req *Request, resp *Response, s *goquery.Selection, i int) *HTMLElement {
+	n := s.Get(0)
+	return &HTMLElement{
+		Name:       n.Data,
+		Text:       strings.TrimSpace(s.Text()),
+		Index:      i,
+		DOM:        s,
+		Request:    req,
+		Response:   resp,
+		attributes: n.Attr,
+	}
+}
+
+// Attr returns the selected attribute of a HTMLElement or empty string
+// if no attribute found
+func (h *HTMLElement) Attr(k string) string {
+	for _, a := range h.attributes {
+		if a.Key == k {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// ChildText returns the whitespace-trimmed text content of the first
+// child matching selector, or the element's own text if selector is
+// empty. ChildText returns an empty string if nothing matches.
+func (h *HTMLElement) ChildText(selector string) string {
+	if selector == "" {
+		return h.Text
+	}
+	return strings.TrimSpace(h.DOM.Find(selector).First().Text())
+}
+
+// ChildTexts returns the whitespace-trimmed text content of every child
+// matching selector
+func (h *HTMLElement) ChildTexts(selector string) []string {
+	var texts []string
+	h.DOM.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		texts = append(texts, strings.TrimSpace(s.Text()))
+	})
+	return texts
+}
+
+// ChildAttr returns the selected attribute of the first child matching
+// selector, or an empty string if nothing matches
+func (h *HTMLElement) ChildAttr(selector, attr string) string {
+	if v, ok := h.DOM.Find(selector).First().Attr(attr); ok {
+		return v
+	}
+	return ""
+}
+
+// ChildAttrs returns the selected attribute of every child matching
+// selector that has it set
+func (h *HTMLElement) ChildAttrs(selector, attr string) []string {
+	var attrs []string
+	h.DOM.Find(selector).Each(func(_ int, s *goquery.Selection) {
+		if v, ok := s.Attr(attr); ok {
+			attrs = append(attrs, v)
+		}
+	})
+	return attrs
+}
+
+// ForEach calls fn on every child matching selector, passing its index
+// within the matched set
+func (h *HTMLElement) ForEach(selector string, fn func(int, *HTMLElement)) {
+	h.DOM.Find(selector).Each(func(i int, s *goquery.Selection) {
+		fn(i, newHTMLElement(h.Request, h.Response, s, i))
+	})
+}
+
+// ForEachWithBreak calls fn on every child matching selector, passing its
+// index within the matched set, and stops iterating as soon as fn returns
+// false
+func (h *HTMLElement) ForEachWithBreak(selector string, fn func(int, *HTMLElement) bool) {
+	h.DOM.Find(selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		return fn(i, newHTMLElement(h.Request, h.Response, s, i))
+	})
+}
+
+// handleOnHTML matches the response body against every registered
+// goquery selector and invokes the matching OnHTML callbacks
+func (c *Collector) handleOnHTML(body []byte, req *Request, resp *Response) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewBuffer(body))
+	if err != nil {
+		return
+	}
+	for expr, f := range c.htmlCallbacks {
+		doc.Find(expr).Each(func(i int, s *goquery.Selection) {
+			f(newHTMLElement(req, resp, s, i))
+		})
+	}
+}
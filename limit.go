@@ -0,0 +1,76 @@
+package colly
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LimitRule provides connection restrictions for domains.
+// Both DomainRegexp and DomainGlob can be used to specify
+// the included domains patterns, but at least one of them
+// is required.
+type LimitRule struct {
+	// DomainRegexp is a regular expression to match against domains
+	DomainRegexp string
+	// DomainGlob is a glob pattern to match against domains
+	DomainGlob string
+	// Delay is the duration to wait before creating a new request
+	// to the matching domains
+	Delay time.Duration
+	// RandomDelay is the extra randomized duration to wait added to Delay
+	// before creating a new request
+	RandomDelay time.Duration
+	// Parallelism specifies the maximum number of concurrent requests
+	// allowed to the matching domains
+	Parallelism    int
+	compiledRegexp *regexp.Regexp
+	compiledGlob   *regexp.Regexp
+}
+
+// Init initializes the private matchers of LimitRule from its
+// DomainRegexp/DomainGlob fields. It must be called before Match.
+func (r *LimitRule) Init() error {
+	var err error
+	if r.DomainRegexp != "" {
+		if r.compiledRegexp, err = regexp.Compile(r.DomainRegexp); err != nil {
+			return err
+		}
+	}
+	if r.DomainGlob != "" {
+		if r.compiledGlob, err = globToRegexp(r.DomainGlob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match checks whether the rule applies to the given domain
+func (r *LimitRule) Match(domain string) bool {
+	if r.compiledRegexp != nil && r.compiledRegexp.MatchString(domain) {
+		return true
+	}
+	if r.compiledGlob != nil && r.compiledGlob.MatchString(domain) {
+		return true
+	}
+	return false
+}
+
+// globToRegexp turns a glob pattern such as "*.example.com" into a
+// compiled regular expression, translating "*" to ".*" and "?" to "."
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,181 @@
+package colly
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultQueueWorkers is the number of goroutines a Collector's Queue
+// uses to dispatch scrape jobs. Actual per-host concurrency is governed
+// separately by LimitRule.Parallelism.
+const defaultQueueWorkers = 16
+
+// serializableQueueJob is the JSON-friendly projection of a queued scrape
+// call, stored through Storage.AddRequest/GetRequest so that Collectors
+// sharing a backend (e.g. Redis) across processes pull from the same
+// work queue. It mirrors serializableRequest; Ctx carries only the
+// string values set through Context.Put.
+type serializableQueueJob struct {
+	URL     string
+	Method  string
+	Depth   int
+	Body    []byte
+	Headers http.Header
+	Ctx     map[string]string
+}
+
+// Queue dispatches queued scrape calls across a fixed pool of worker
+// goroutines. It is used internally by Collector when Async is enabled.
+//
+// Jobs themselves live in c.storage, not in process memory: push
+// serializes a job and hands it to Storage.AddRequest, and a worker
+// pulls the next one with Storage.GetRequest. pending is an in-process
+// doorbell -- a count of jobs push has added that no worker has yet
+// woken up for -- so workers sleep on cond instead of busy-polling
+// Storage, while the jobs themselves stay resumable and shareable across
+// processes pointed at the same backend.
+//
+// push never blocks on a worker to drain anything: workers themselves
+// call push when OnHTML handlers issue further Visit calls, and Storage
+// writes don't require a reader on the other end, so a worker can never
+// deadlock against its own drain loop the way it could with a bounded
+// channel.
+type Queue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	pending   int
+	collector *Collector
+}
+
+// newQueue creates a Queue with the given number of worker goroutines
+// bound to c
+func newQueue(c *Collector, workers int) *Queue {
+	q := &Queue{collector: c}
+	q.cond = sync.NewCond(&q.mu)
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+func (q *Queue) worker() {
+	for {
+		q.mu.Lock()
+		for q.pending == 0 {
+			q.cond.Wait()
+		}
+		q.pending--
+		q.mu.Unlock()
+
+		data, err := q.collector.storage.GetRequest()
+		if err != nil || data == nil {
+			continue
+		}
+		var j serializableQueueJob
+		if err := json.Unmarshal(data, &j); err != nil {
+			continue
+		}
+		ctx := NewContext()
+		for k, v := range j.Ctx {
+			ctx.Put(k, v)
+		}
+		q.collector.fetch(j.URL, j.Method, j.Depth, j.Body, ctx, j.Headers)
+	}
+}
+
+// push serializes a job and hands it to Storage.AddRequest, then wakes a
+// worker to pick it up
+func (q *Queue) push(u, method string, depth int, body []byte, ctx *Context, hdr http.Header) error {
+	ctxValues := map[string]string{}
+	if ctx != nil {
+		ctx.lock.Lock()
+		for k, v := range ctx.contextMap {
+			ctxValues[k] = v
+		}
+		ctx.lock.Unlock()
+	}
+	data, err := json.Marshal(serializableQueueJob{URL: u, Method: method, Depth: depth, Body: body, Headers: hdr, Ctx: ctxValues})
+	if err != nil {
+		return err
+	}
+	if err := q.collector.storage.AddRequest(data); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+	return nil
+}
+
+// domainLimiter enforces LimitRule.Parallelism and LimitRule.Delay for a
+// single host using a buffered channel as a semaphore and a blocking
+// sleep as a delay gate.
+type domainLimiter struct {
+	sem         chan struct{}
+	delay       time.Duration
+	randomDelay time.Duration
+	mu          sync.Mutex
+}
+
+// wait blocks until a concurrency slot is available for the host and
+// then sleeps for Delay plus a random jitter up to RandomDelay
+func (l *domainLimiter) wait() {
+	if l.sem != nil {
+		l.sem <- struct{}{}
+	}
+	if l.delay > 0 || l.randomDelay > 0 {
+		d := l.delay
+		if l.randomDelay > 0 {
+			d += time.Duration(rand.Int63n(int64(l.randomDelay)))
+		}
+		time.Sleep(d)
+	}
+}
+
+// done releases the concurrency slot acquired by wait
+func (l *domainLimiter) done() {
+	if l.sem != nil {
+		<-l.sem
+	}
+}
+
+// limiterFor returns the domainLimiter for domain, creating one from the
+// first matching LimitRule on first use
+func (c *Collector) limiterFor(domain string) *domainLimiter {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if l, ok := c.limiters[domain]; ok {
+		return l
+	}
+	l := &domainLimiter{}
+	for _, rule := range c.limitRules {
+		if !rule.Match(domain) {
+			continue
+		}
+		if rule.Parallelism > 0 {
+			l.sem = make(chan struct{}, rule.Parallelism)
+		}
+		l.delay = rule.Delay
+		l.randomDelay = rule.RandomDelay
+		break
+	}
+	c.limiters[domain] = l
+	return l
+}
+
+// Limit adds a new LimitRule to the Collector. The rule's DomainRegexp
+// or DomainGlob is compiled immediately so Limit returns an error for an
+// invalid pattern instead of failing silently on the first request.
+func (c *Collector) Limit(rule *LimitRule) error {
+	if err := rule.Init(); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.limitRules = append(c.limitRules, rule)
+	c.lock.Unlock()
+	return nil
+}
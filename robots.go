@@ -0,0 +1,46 @@
+package colly
+
+import (
+	"net/url"
+
+	"github.com/temoto/robotstxt"
+)
+
+// checkRobots fetches (and caches) u.Host's robots.txt and returns
+// ErrRobotsTxtBlocked if the configured UserAgent is disallowed from
+// visiting u.Path
+func (c *Collector) checkRobots(u *url.URL) error {
+	c.lock.Lock()
+	robot, ok := c.robotsMap[u.Host]
+	c.lock.Unlock()
+	if !ok {
+		robot = c.fetchRobots(u)
+		c.lock.Lock()
+		c.robotsMap[u.Host] = robot
+		c.lock.Unlock()
+	}
+	if robot == nil {
+		return nil
+	}
+	group := robot.FindGroup(c.UserAgent)
+	if group != nil && !group.Test(u.Path) {
+		return ErrRobotsTxtBlocked
+	}
+	return nil
+}
+
+// fetchRobots downloads and parses the robots.txt of u's host. A nil
+// result (fetch/parse failure) is treated as "no restrictions".
+func (c *Collector) fetchRobots(u *url.URL) *robotstxt.RobotsData {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := c.client.Get(robotsURL.String())
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	robot, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil
+	}
+	return robot
+}
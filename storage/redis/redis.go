@@ -0,0 +1,92 @@
+// Package redis provides a storage.Storage implementation backed by
+// Redis, allowing multiple Collector processes to share visited-URL,
+// cookie and queue state for distributed or resumable crawls.
+package redis
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/go-redis/redis"
+
+	"github.com/aleksey0909/collygolang/storage"
+)
+
+// Storage implements storage.Storage on top of a Redis instance.
+type Storage struct {
+	// Address is the Redis server address, e.g. "localhost:6379"
+	Address string
+	// Password is the Redis AUTH password, empty if not required
+	Password string
+	// DB is the Redis database index to use
+	DB int
+	// Prefix is prepended to every key this Storage writes, allowing
+	// several Collectors to share one Redis instance
+	Prefix string
+	client *redis.Client
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+// Init connects to Redis and verifies the connection with a PING
+func (s *Storage) Init() error {
+	s.client = redis.NewClient(&redis.Options{
+		Addr:     s.Address,
+		Password: s.Password,
+		DB:       s.DB,
+	})
+	return s.client.Ping().Err()
+}
+
+func (s *Storage) key(parts ...string) string {
+	k := s.Prefix
+	for _, p := range parts {
+		k += ":" + p
+	}
+	return k
+}
+
+// Visited implements storage.Storage.Visited
+func (s *Storage) Visited(requestID uint64) error {
+	return s.client.Set(s.key("visited", strconv.FormatUint(requestID, 10)), "1", 0).Err()
+}
+
+// IsVisited implements storage.Storage.IsVisited
+func (s *Storage) IsVisited(requestID uint64) (bool, error) {
+	n, err := s.client.Exists(s.key("visited", strconv.FormatUint(requestID, 10))).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Cookies implements storage.Storage.Cookies
+func (s *Storage) Cookies(u *url.URL) string {
+	val, _ := s.client.Get(s.key("cookies", u.Host)).Result()
+	return val
+}
+
+// SetCookies implements storage.Storage.SetCookies
+func (s *Storage) SetCookies(u *url.URL, cookies string) {
+	s.client.Set(s.key("cookies", u.Host), cookies, 0)
+}
+
+// AddRequest implements storage.Storage.AddRequest
+func (s *Storage) AddRequest(r []byte) error {
+	return s.client.RPush(s.key("queue"), r).Err()
+}
+
+// GetRequest implements storage.Storage.GetRequest
+func (s *Storage) GetRequest() ([]byte, error) {
+	r, err := s.client.LPop(s.key("queue")).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return r, err
+}
+
+// QueueSize implements storage.Storage.QueueSize
+func (s *Storage) QueueSize() (int, error) {
+	n, err := s.client.LLen(s.key("queue")).Result()
+	return int(n), err
+}
@@ -0,0 +1,113 @@
+// Package sqlite3 provides a storage.Storage implementation backed by a
+// single SQLite3 database file, giving a Collector durable persistence
+// across restarts without an external service.
+package sqlite3
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/aleksey0909/collygolang/storage"
+)
+
+// Storage implements storage.Storage on top of a SQLite3 database file.
+type Storage struct {
+	// Filename is the path to the SQLite3 database file
+	Filename string
+	db       *sql.DB
+}
+
+var _ storage.Storage = (*Storage)(nil)
+
+// Init opens Filename and creates the tables used to track visited
+// requests, cookies and the request queue
+func (s *Storage) Init() error {
+	db, err := sql.Open("sqlite3", s.Filename)
+	if err != nil {
+		return err
+	}
+	s.db = db
+	_, err = s.db.Exec(`
+		PRAGMA busy_timeout = 5000;
+		CREATE TABLE IF NOT EXISTS visited (request_id INTEGER PRIMARY KEY);
+		CREATE TABLE IF NOT EXISTS cookies (host TEXT PRIMARY KEY, cookies TEXT);
+		CREATE TABLE IF NOT EXISTS queue (id INTEGER PRIMARY KEY AUTOINCREMENT, request BLOB);
+	`)
+	return err
+}
+
+// Visited implements storage.Storage.Visited
+func (s *Storage) Visited(requestID uint64) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO visited (request_id) VALUES (?)`, requestID)
+	return err
+}
+
+// IsVisited implements storage.Storage.IsVisited
+func (s *Storage) IsVisited(requestID uint64) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM visited WHERE request_id = ?`, requestID).Scan(&count)
+	return count > 0, err
+}
+
+// Cookies implements storage.Storage.Cookies
+func (s *Storage) Cookies(u *url.URL) string {
+	var cookies string
+	s.db.QueryRow(`SELECT cookies FROM cookies WHERE host = ?`, u.Host).Scan(&cookies)
+	return cookies
+}
+
+// SetCookies implements storage.Storage.SetCookies
+func (s *Storage) SetCookies(u *url.URL, cookies string) {
+	s.db.Exec(`INSERT OR REPLACE INTO cookies (host, cookies) VALUES (?, ?)`, u.Host, cookies)
+}
+
+// AddRequest implements storage.Storage.AddRequest
+func (s *Storage) AddRequest(r []byte) error {
+	_, err := s.db.Exec(`INSERT INTO queue (request) VALUES (?)`, r)
+	return err
+}
+
+// GetRequest implements storage.Storage.GetRequest. The select and delete
+// run inside a single transaction, and the delete's RowsAffected is
+// checked, so that two GetRequest calls racing on the same row -- as
+// Collector's worker pool does once Async is on -- can't both dispatch
+// it: whichever transaction's DELETE commits second deletes zero rows
+// and reports the queue as empty instead of handing out a duplicate.
+func (s *Storage) GetRequest() ([]byte, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var request []byte
+	row := tx.QueryRow(`SELECT id, request FROM queue ORDER BY id LIMIT 1`)
+	if err := row.Scan(&id, &request); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	res, err := tx.Exec(`DELETE FROM queue WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, nil
+	}
+	return request, tx.Commit()
+}
+
+// QueueSize implements storage.Storage.QueueSize
+func (s *Storage) QueueSize() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM queue`).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,108 @@
+// Package storage defines the pluggable persistence backend used by a
+// Collector to track visited URLs, cookies and (for distributed crawls)
+// queued requests.
+package storage
+
+import (
+	"net/url"
+	"sync"
+)
+
+// Storage is the interface a Collector uses to persist visited-URL
+// state, cookies and queued requests. Implementations sharing a single
+// backend (e.g. Redis) across multiple processes make resumable and
+// distributed scraping possible.
+type Storage interface {
+	// Init initializes the storage backend
+	Init() error
+	// Visited marks a request, identified by requestID, as visited
+	Visited(requestID uint64) error
+	// IsVisited reports whether requestID was already visited
+	IsVisited(requestID uint64) (bool, error)
+	// Cookies returns the stored cookies for u, or an empty string if
+	// none are stored
+	Cookies(u *url.URL) string
+	// SetCookies stores cookies for u
+	SetCookies(u *url.URL, cookies string)
+	// AddRequest pushes a serialized request onto the queue
+	AddRequest(r []byte) error
+	// GetRequest pops the oldest serialized request from the queue. It
+	// returns a nil slice and a nil error when the queue is empty.
+	GetRequest() ([]byte, error)
+	// QueueSize returns the number of requests currently queued
+	QueueSize() (int, error)
+}
+
+// InMemoryStorage is the default Storage, backed by in-process maps and a
+// slice. All state is lost when the process exits.
+type InMemoryStorage struct {
+	visited map[uint64]bool
+	cookies map[string]string
+	queue   [][]byte
+	lock    sync.Mutex
+}
+
+// Init initializes the in-memory storage's maps
+func (s *InMemoryStorage) Init() error {
+	s.visited = make(map[uint64]bool)
+	s.cookies = make(map[string]string)
+	s.queue = make([][]byte, 0, 8)
+	return nil
+}
+
+// Visited implements Storage.Visited
+func (s *InMemoryStorage) Visited(requestID uint64) error {
+	s.lock.Lock()
+	s.visited[requestID] = true
+	s.lock.Unlock()
+	return nil
+}
+
+// IsVisited implements Storage.IsVisited
+func (s *InMemoryStorage) IsVisited(requestID uint64) (bool, error) {
+	s.lock.Lock()
+	visited := s.visited[requestID]
+	s.lock.Unlock()
+	return visited, nil
+}
+
+// Cookies implements Storage.Cookies
+func (s *InMemoryStorage) Cookies(u *url.URL) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.cookies[u.Host]
+}
+
+// SetCookies implements Storage.SetCookies
+func (s *InMemoryStorage) SetCookies(u *url.URL, cookies string) {
+	s.lock.Lock()
+	s.cookies[u.Host] = cookies
+	s.lock.Unlock()
+}
+
+// AddRequest implements Storage.AddRequest
+func (s *InMemoryStorage) AddRequest(r []byte) error {
+	s.lock.Lock()
+	s.queue = append(s.queue, r)
+	s.lock.Unlock()
+	return nil
+}
+
+// GetRequest implements Storage.GetRequest
+func (s *InMemoryStorage) GetRequest() ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.queue) == 0 {
+		return nil, nil
+	}
+	r := s.queue[0]
+	s.queue = s.queue[1:]
+	return r, nil
+}
+
+// QueueSize implements Storage.QueueSize
+func (s *InMemoryStorage) QueueSize() (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.queue), nil
+}
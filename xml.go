@@ -0,0 +1,98 @@
+package colly
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"golang.org/x/net/html"
+)
+
+// XMLElement is the representation of a XML/HTML tag matched by an XPath
+// query passed to OnXML
+type XMLElement struct {
+	// Name is the name of the tag
+	Name string
+	// Text is the inner text content of the element
+	Text string
+	// Request is the request object of the element's document
+	Request *Request
+	// Response is the Response object of the element's document
+	Response *Response
+	attrs    interface{}
+}
+
+// XMLCallback is a type alias for OnXML callback functions
+type XMLCallback func(*XMLElement)
+
+func newXMLElementFromHTMLNode(req *Request, resp *Response, n *html.Node) *XMLElement {
+	return &XMLElement{
+		Name:     n.Data,
+		Text:     htmlquery.InnerText(n),
+		Request:  req,
+		Response: resp,
+		attrs:    n.Attr,
+	}
+}
+
+func newXMLElementFromXMLNode(req *Request, resp *Response, n *xmlquery.Node) *XMLElement {
+	return &XMLElement{
+		Name:     n.Data,
+		Text:     n.InnerText(),
+		Request:  req,
+		Response: resp,
+		attrs:    n.Attr,
+	}
+}
+
+// Attr returns the selected attribute of a XMLElement or empty string if
+// no attribute was found
+func (x *XMLElement) Attr(k string) string {
+	switch attrs := x.attrs.(type) {
+	case []html.Attribute:
+		for _, a := range attrs {
+			if a.Key == k {
+				return a.Val
+			}
+		}
+	case []xmlquery.Attr:
+		for _, a := range attrs {
+			if a.Name.Local == k {
+				return a.Value
+			}
+		}
+	}
+	return ""
+}
+
+// handleOnXML matches the response body against every registered XPath
+// query, parsing it as XML or HTML depending on the response's
+// Content-Type
+func (c *Collector) handleOnXML(body []byte, req *Request, resp *Response) {
+	contentType := ""
+	if resp.Headers != nil {
+		contentType = strings.ToLower(resp.Headers.Get("Content-Type"))
+	}
+	if strings.Contains(contentType, "xml") {
+		doc, err := xmlquery.Parse(bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		for expr, f := range c.xmlCallbacks {
+			for _, n := range xmlquery.Find(doc, expr) {
+				f(newXMLElementFromXMLNode(req, resp, n))
+			}
+		}
+		return
+	}
+	doc, err := htmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	for expr, f := range c.xmlCallbacks {
+		for _, n := range htmlquery.Find(doc, expr) {
+			f(newXMLElementFromHTMLNode(req, resp, n))
+		}
+	}
+}